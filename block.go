@@ -0,0 +1,230 @@
+package wave
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// blockBytesPerSample returns the number of bytes one channel of one frame
+// occupies on the wire for the given effective audio format and bit depth.
+func blockBytesPerSample(fmtCode uint16, bitsPerSample uint16) int {
+	if fmtCode == FormatALaw || fmtCode == FormatMULaw {
+		return 1
+	}
+	return int(bitsPerSample) / 8
+}
+
+// decodeIntSample decodes one channel's worth of wire bytes (as produced
+// by blockBytesPerSample) into a linear int64 sample.
+func decodeIntSample(fmtCode uint16, bitsPerSample uint16, b []byte) int64 {
+	if fmtCode == FormatALaw || fmtCode == FormatMULaw {
+		return int64(decodeCompanded(fmtCode, b[0]))
+	}
+	switch bitsPerSample {
+	case 8:
+		return int64(int8(b[0]))
+	case 16:
+		return int64(int16(binary.LittleEndian.Uint16(b)))
+	case 24:
+		lo := binary.LittleEndian.Uint16(b[0:2])
+		hi := int8(b[2])
+		return int64(hi)<<16 + int64(lo)
+	case 32:
+		return int64(int32(binary.LittleEndian.Uint32(b)))
+	default:
+		panic(fmt.Sprintf("unknown bits per sample %d", bitsPerSample))
+	}
+}
+
+// encodeIntSample encodes sample into the wire bytes for the given
+// effective audio format and bit depth, writing blockBytesPerSample bytes
+// into dst.
+func encodeIntSample(fmtCode uint16, bitsPerSample uint16, sample int64, dst []byte) {
+	if fmtCode == FormatALaw || fmtCode == FormatMULaw {
+		dst[0] = encodeCompanded(fmtCode, clipInt16(sample))
+		return
+	}
+	switch bitsPerSample {
+	case 8:
+		dst[0] = byte(int8(sample))
+	case 16:
+		binary.LittleEndian.PutUint16(dst, uint16(int16(sample)))
+	case 24:
+		v := int32(sample)
+		binary.LittleEndian.PutUint16(dst[0:2], uint16(v))
+		dst[2] = byte(v >> 16)
+	case 32:
+		binary.LittleEndian.PutUint32(dst, uint32(int32(sample)))
+	default:
+		panic(fmt.Sprintf("unknown bits per sample %d", bitsPerSample))
+	}
+}
+
+// ReadIntBlock reads up to frames frames in a single buffered read,
+// decoding samples directly with encoding/binary's byte order helpers
+// instead of the per-sample, reflection-driven binary.Read used by
+// ReadInt. dst must have a length of at least frames; ReadIntBlock reuses
+// each dst[j] it fills that already has length channels, and allocates a
+// channels-length slice for any other. It returns
+// the number of frames actually read and, as with io.Reader, may return
+// io.EOF alongside a final partial read or on a subsequent call once the
+// data chunk is exhausted.
+func (r *Reader) ReadIntBlock(dst [][]int64, frames int) (int, error) {
+	if frames > len(dst) {
+		return 0, fmt.Errorf("ReadIntBlock: frames %d exceeds len(dst) %d", frames, len(dst))
+	}
+
+	fmtCode := r.H.EffectiveAudioFmt()
+	if fmtCode == FormatIEEEFloat {
+		return 0, fmt.Errorf("cannot ReadIntBlock from an IEEE float wave file: use ReadFloatBlock")
+	}
+
+	channels := int(r.H.Channels)
+	sampleSize := blockBytesPerSample(fmtCode, r.H.BitsPerSample)
+	frameSize := channels * sampleSize
+
+	buf := make([]byte, frames*frameSize)
+	n, err := io.ReadFull(r.R, buf)
+	framesRead := n / frameSize
+
+	for f := 0; f < framesRead; f++ {
+		if len(dst[f]) != channels {
+			dst[f] = make([]int64, channels)
+		}
+		for c := 0; c < channels; c++ {
+			off := f*frameSize + c*sampleSize
+			dst[f][c] = decodeIntSample(fmtCode, r.H.BitsPerSample, buf[off:off+sampleSize])
+		}
+	}
+
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	if framesRead == frames {
+		err = nil
+	}
+	return framesRead, err
+}
+
+// ReadFloatBlock is the IEEE float equivalent of ReadIntBlock: it reads up
+// to frames frames in a single buffered read and decodes them with
+// math.Float32frombits/Float64frombits instead of per-sample binary.Read.
+func (r *Reader) ReadFloatBlock(dst [][]float64, frames int) (int, error) {
+	if frames > len(dst) {
+		return 0, fmt.Errorf("ReadFloatBlock: frames %d exceeds len(dst) %d", frames, len(dst))
+	}
+	if r.H.EffectiveAudioFmt() != FormatIEEEFloat {
+		return 0, fmt.Errorf("cannot ReadFloatBlock from a non IEEE float wave file: AudioFmt %d", r.H.EffectiveAudioFmt())
+	}
+
+	channels := int(r.H.Channels)
+	sampleSize := int(r.H.BitsPerSample) / 8
+	if sampleSize != 4 && sampleSize != 8 {
+		return 0, fmt.Errorf("unexpected BitsPerSample in ReadFloatBlock: want 32 or 64, got %d", r.H.BitsPerSample)
+	}
+	frameSize := channels * sampleSize
+
+	buf := make([]byte, frames*frameSize)
+	n, err := io.ReadFull(r.R, buf)
+	framesRead := n / frameSize
+
+	for f := 0; f < framesRead; f++ {
+		if len(dst[f]) != channels {
+			dst[f] = make([]float64, channels)
+		}
+		for c := 0; c < channels; c++ {
+			off := f*frameSize + c*sampleSize
+			if sampleSize == 4 {
+				dst[f][c] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[off : off+4])))
+			} else {
+				dst[f][c] = math.Float64frombits(binary.LittleEndian.Uint64(buf[off : off+8]))
+			}
+		}
+	}
+
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	if framesRead == frames {
+		err = nil
+	}
+	return framesRead, err
+}
+
+// WriteIntBlock writes every frame in src in a single buffered write,
+// encoding samples directly with encoding/binary's byte order helpers
+// instead of the per-sample, reflection-driven binary.Write used by
+// WriteInt. Unlike WriteInt, WriteIntBlock supports 24 bit PCM, packing
+// each sample's three bytes directly into the buffer.
+func (w *Writer) WriteIntBlock(src [][]int64) error {
+	fmtCode := w.H.EffectiveAudioFmt()
+	if fmtCode == FormatIEEEFloat {
+		return fmt.Errorf("cannot WriteIntBlock to an IEEE float wave file: use WriteFloatBlock")
+	}
+	if w.numSamples >= 0 && w.ctr+len(src) > w.numSamples {
+		return fmt.Errorf("overflow error: attempting to write too many samples: already wrote %d", w.ctr)
+	}
+
+	channels := int(w.H.Channels)
+	sampleSize := blockBytesPerSample(fmtCode, w.H.BitsPerSample)
+	frameSize := channels * sampleSize
+
+	buf := make([]byte, len(src)*frameSize)
+	for f, frame := range src {
+		if len(frame) != channels {
+			return fmt.Errorf("number of samples != channels in WriteIntBlock at frame %d: want %d: got %d", f, channels, len(frame))
+		}
+		for c, sample := range frame {
+			off := f*frameSize + c*sampleSize
+			encodeIntSample(fmtCode, w.H.BitsPerSample, sample, buf[off:off+sampleSize])
+		}
+	}
+
+	if _, err := w.W.Write(buf); err != nil {
+		return fmt.Errorf("error writing block in WriteIntBlock: %s", err)
+	}
+	w.ctr += len(src)
+	return nil
+}
+
+// WriteFloatBlock is the IEEE float equivalent of WriteIntBlock: it
+// encodes every frame in src into a single buffer with
+// math.Float32bits/Float64bits and writes it in one call.
+func (w *Writer) WriteFloatBlock(src [][]float64) error {
+	if w.H.EffectiveAudioFmt() != FormatIEEEFloat {
+		return fmt.Errorf("cannot WriteFloatBlock to a non IEEE float wave file: AudioFmt %d", w.H.AudioFmt)
+	}
+	if w.numSamples >= 0 && w.ctr+len(src) > w.numSamples {
+		return fmt.Errorf("overflow error: attempting to write too many samples: already wrote %d", w.ctr)
+	}
+
+	channels := int(w.H.Channels)
+	sampleSize := int(w.H.BitsPerSample) / 8
+	if sampleSize != 4 && sampleSize != 8 {
+		return fmt.Errorf("only 32 or 64 bit floats are supported. bitsPerSample in Header is set to: %d", w.H.BitsPerSample)
+	}
+	frameSize := channels * sampleSize
+
+	buf := make([]byte, len(src)*frameSize)
+	for f, frame := range src {
+		if len(frame) != channels {
+			return fmt.Errorf("number of samples != channels in WriteFloatBlock at frame %d: want %d: got %d", f, channels, len(frame))
+		}
+		for c, sample := range frame {
+			off := f*frameSize + c*sampleSize
+			if sampleSize == 4 {
+				binary.LittleEndian.PutUint32(buf[off:off+4], math.Float32bits(float32(sample)))
+			} else {
+				binary.LittleEndian.PutUint64(buf[off:off+8], math.Float64bits(sample))
+			}
+		}
+	}
+
+	if _, err := w.W.Write(buf); err != nil {
+		return fmt.Errorf("error writing block in WriteFloatBlock: %s", err)
+	}
+	w.ctr += len(src)
+	return nil
+}
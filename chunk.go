@@ -0,0 +1,226 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Chunk describes a single RIFF chunk discovered while scanning a wave
+// file, including chunks that NewReader does not otherwise interpret.
+type Chunk struct {
+	ID   [4]byte // four character chunk ID, e.g. "data", "LIST"
+	Size uint32  // chunk payload size in bytes, excluding the 8 byte ID+Size header
+}
+
+// String returns the chunk ID as a string, for logging and debugging.
+func (c Chunk) String() string {
+	return string(c.ID[:])
+}
+
+// Metadata holds descriptive information recovered from an INFO list
+// chunk and a broadcast extension chunk. Fields are left at their zero
+// value when the corresponding chunk or sub-chunk is not present.
+type Metadata struct {
+	Artist   string // IART
+	Title    string // INAM
+	Album    string // IPRD
+	Comment  string // ICMT
+	Genre    string // IGNR
+	Date     string // ICRD
+	Software string // ISFT
+
+	// Broadcast holds the descriptive fields of a "bext" chunk, or nil if
+	// the file did not carry one.
+	Broadcast *BroadcastExt
+}
+
+// BroadcastExt holds the descriptive text fields of an EBU Broadcast Wave
+// Format "bext" chunk. Timecode and UMID fields are not currently
+// surfaced.
+type BroadcastExt struct {
+	Description     string
+	Originator      string
+	OriginatorRef   string
+	OriginationDate string
+	OriginationTime string
+}
+
+// bextFixedSize is the length in bytes of the descriptive text fields at
+// the start of a "bext" chunk, ahead of the timecode, version and UMID
+// fields this package does not interpret.
+const bextFixedSize = 256 + 32 + 32 + 10 + 8
+
+// readChunkHeader reads the 4 byte chunk ID and 4 byte little endian size
+// that precede every RIFF chunk.
+func readChunkHeader(r io.Reader) ([4]byte, uint32, error) {
+	var id [4]byte
+	if _, err := io.ReadFull(r, id[:]); err != nil {
+		return id, 0, err
+	}
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return id, 0, err
+	}
+	return id, size, nil
+}
+
+// skip discards n bytes from r, used to step over chunk payloads and
+// sections of a chunk that are not otherwise interpreted.
+func skip(r io.Reader, n uint32) error {
+	if n == 0 {
+		return nil
+	}
+	_, err := io.CopyN(io.Discard, r, int64(n))
+	return err
+}
+
+// readFmtChunk parses an "fmt " chunk of the given size into h. Besides
+// the standard 16 byte common form it understands the 18 byte form (a
+// trailing cbSize that is ordinarily 0) and the 40 byte
+// WAVE_FORMAT_EXTENSIBLE form, populating ValidBitsPerSample, ChannelMask
+// and SubFormat in the latter case. readFmtChunk does not set
+// h.FmtChunkSize; its callers record the canonical 16 regardless of size,
+// so Header.Validate keeps working against the fields it understands. Any
+// bytes beyond what is parsed are discarded.
+func readFmtChunk(r io.Reader, size uint32, h *Header) error {
+	if size < 16 {
+		return fmt.Errorf("fmt chunk too small: want at least 16 bytes, got %d", size)
+	}
+
+	var common struct {
+		AudioFmt      uint16
+		Channels      uint16
+		SamplesPerSec uint32
+		BytesPerSec   uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &common); err != nil {
+		return fmt.Errorf("error reading fmt chunk: %s", err)
+	}
+	h.AudioFmt = common.AudioFmt
+	h.Channels = common.Channels
+	h.SamplesPerSec = common.SamplesPerSec
+	h.BytesPerSec = common.BytesPerSec
+	h.BlockAlign = common.BlockAlign
+	h.BitsPerSample = common.BitsPerSample
+
+	if size == 16 {
+		return nil
+	}
+
+	var cbSize uint16
+	if err := binary.Read(r, binary.LittleEndian, &cbSize); err != nil {
+		return fmt.Errorf("error reading fmt extension size: %s", err)
+	}
+	consumed := uint32(2)
+
+	if common.AudioFmt == FormatExtensible && cbSize >= 22 && size >= 40 {
+		var ext struct {
+			ValidBitsPerSample uint16
+			ChannelMask        uint32
+			SubFormat          [16]byte
+		}
+		if err := binary.Read(r, binary.LittleEndian, &ext); err != nil {
+			return fmt.Errorf("error reading fmt extensible fields: %s", err)
+		}
+		h.ValidBitsPerSample = ext.ValidBitsPerSample
+		h.ChannelMask = ext.ChannelMask
+		h.SubFormat = ext.SubFormat
+		consumed += 22
+	}
+
+	return skip(r, size-16-consumed)
+}
+
+// readListChunk parses a "LIST" chunk of the given size. Only the INFO
+// list type is interpreted; every other list type is skipped whole.
+func readListChunk(r io.Reader, size uint32, meta *Metadata) error {
+	if size < 4 {
+		return skip(r, size)
+	}
+
+	var listType [4]byte
+	if _, err := io.ReadFull(r, listType[:]); err != nil {
+		return fmt.Errorf("error reading LIST type: %s", err)
+	}
+	remaining := size - 4
+	if !byte4Cmp(listType, "INFO") {
+		return skip(r, remaining)
+	}
+
+	for remaining >= 8 {
+		id, n, err := readChunkHeader(r)
+		if err != nil {
+			return fmt.Errorf("error reading INFO sub-chunk header: %s", err)
+		}
+		remaining -= 8
+		if n > remaining {
+			return fmt.Errorf("INFO sub-chunk %s size %d exceeds remaining LIST bytes %d", id, n, remaining)
+		}
+
+		text, err := readPaddedString(r, n)
+		if err != nil {
+			return fmt.Errorf("error reading INFO sub-chunk %s: %s", id, err)
+		}
+		remaining -= n + n%2
+
+		switch {
+		case byte4Cmp(id, "IART"):
+			meta.Artist = text
+		case byte4Cmp(id, "INAM"):
+			meta.Title = text
+		case byte4Cmp(id, "IPRD"):
+			meta.Album = text
+		case byte4Cmp(id, "ICMT"):
+			meta.Comment = text
+		case byte4Cmp(id, "IGNR"):
+			meta.Genre = text
+		case byte4Cmp(id, "ICRD"):
+			meta.Date = text
+		case byte4Cmp(id, "ISFT"):
+			meta.Software = text
+		}
+	}
+	return skip(r, remaining)
+}
+
+// readPaddedString reads an n byte, NUL-terminated, word-aligned text
+// field as used by RIFF INFO sub-chunks, trimming trailing NUL bytes.
+func readPaddedString(r io.Reader, n uint32) (string, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	if n%2 == 1 {
+		if _, err := io.ReadFull(r, make([]byte, 1)); err != nil {
+			return "", err
+		}
+	}
+	return string(bytes.TrimRight(buf, "\x00")), nil
+}
+
+// readBextChunk parses the descriptive text fields from an EBU Broadcast
+// Wave Format "bext" chunk of the given size. It returns nil, nil if the
+// chunk is smaller than the fields it understands.
+func readBextChunk(r io.Reader, size uint32) (*BroadcastExt, error) {
+	if size < bextFixedSize {
+		return nil, skip(r, size)
+	}
+
+	buf := make([]byte, bextFixedSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("error reading bext chunk: %s", err)
+	}
+
+	bext := &BroadcastExt{
+		Description:     string(bytes.TrimRight(buf[0:256], "\x00")),
+		Originator:      string(bytes.TrimRight(buf[256:288], "\x00")),
+		OriginatorRef:   string(bytes.TrimRight(buf[288:320], "\x00")),
+		OriginationDate: string(bytes.TrimRight(buf[320:330], "\x00")),
+		OriginationTime: string(bytes.TrimRight(buf[330:338], "\x00")),
+	}
+	return bext, skip(r, size-bextFixedSize)
+}
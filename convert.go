@@ -0,0 +1,286 @@
+package wave
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// normalizationDivisor returns the divisor that scales a sample read via
+// ReadInt into the [-1, 1] range for the given effective audio format and
+// bit depth.
+func normalizationDivisor(fmtCode uint16, bitsPerSample uint16) float64 {
+	if fmtCode == FormatALaw || fmtCode == FormatMULaw {
+		// decodeCompanded always expands to the 16 bit linear range.
+		return 32768.0
+	}
+	switch bitsPerSample {
+	case 8:
+		return 128.0
+	case 16:
+		return 32768.0
+	case 24:
+		return 8388608.0
+	case 32:
+		return 2147483648.0
+	default:
+		return 1.0
+	}
+}
+
+// ReadNormalizedFloat reads one frame of samples and scales it to the
+// [-1, 1] range, regardless of whether the file stores PCM, IEEE float or
+// companded samples. As with ReadInt and ReadFloat, each slice element
+// corresponds to a channel. This saves callers from having to know the
+// file's bit depth to normalize amplitude themselves.
+func (r *Reader) ReadNormalizedFloat() ([]float64, error) {
+	fmtCode := r.H.EffectiveAudioFmt()
+	if fmtCode == FormatIEEEFloat {
+		return r.ReadFloat()
+	}
+
+	samples, err := r.ReadInt()
+	if err != nil {
+		return nil, err
+	}
+
+	divisor := normalizationDivisor(fmtCode, r.H.BitsPerSample)
+	ret := make([]float64, len(samples))
+	for j, s := range samples {
+		ret[j] = float64(s) / divisor
+	}
+	return ret, nil
+}
+
+// ResampleMode selects the interpolation algorithm a Resampler uses to
+// produce samples between the frames decoded from its source Reader.
+type ResampleMode int
+
+const (
+	// ResampleLinear interpolates linearly between the two nearest source
+	// frames. Cheap, and adequate outside of critical listening.
+	ResampleLinear ResampleMode = iota
+	// ResampleSinc uses a windowed (Lanczos) sinc kernel, trading CPU cost
+	// for fewer aliasing artifacts than ResampleLinear.
+	ResampleSinc
+)
+
+// sincTaps is the number of source frames considered on either side of
+// the interpolation point by ResampleSinc.
+const sincTaps = 8
+
+// Resampler wraps a *Reader and produces normalized frames at a target
+// sample rate and channel count, converting from whatever the underlying
+// file declares. Only mono and stereo targets are supported: mono sources
+// read by a stereo Resampler are upmixed by duplicating the single
+// channel, and stereo sources read by a mono Resampler are downmixed by
+// averaging the two channels.
+type Resampler struct {
+	r           *Reader
+	mode        ResampleMode
+	srcRate     float64
+	dstRate     float64
+	dstChannels int
+
+	history   [][]float64 // decoded, channel-mixed source frames, oldest first
+	base      int         // absolute source frame index of history[0]
+	pos       float64     // absolute source frame position of the next output sample
+	exhausted bool        // true once the source Reader has returned io.EOF
+}
+
+// NewResampler creates a Resampler reading from r and producing frames at
+// targetSamplesPerSec and targetChannels, using the given interpolation
+// mode.
+func NewResampler(r *Reader, targetSamplesPerSec, targetChannels int, mode ResampleMode) (*Resampler, error) {
+	if targetChannels != 1 && targetChannels != 2 {
+		return nil, fmt.Errorf("NewResampler: only mono or stereo targets are supported, got %d channels", targetChannels)
+	}
+	if targetSamplesPerSec <= 0 {
+		return nil, fmt.Errorf("NewResampler: targetSamplesPerSec must be positive, got %d", targetSamplesPerSec)
+	}
+	return &Resampler{
+		r:           r,
+		mode:        mode,
+		srcRate:     float64(r.H.SamplesPerSec),
+		dstRate:     float64(targetSamplesPerSec),
+		dstChannels: targetChannels,
+	}, nil
+}
+
+// mixChannels converts a decoded source frame to the Resampler's target
+// channel count.
+func (s *Resampler) mixChannels(frame []float64) []float64 {
+	if len(frame) == s.dstChannels {
+		return frame
+	}
+	if s.dstChannels == 1 {
+		sum := 0.0
+		for _, v := range frame {
+			sum += v
+		}
+		return []float64{sum / float64(len(frame))}
+	}
+	return []float64{frame[0], frame[0]}
+}
+
+// ensure decodes source frames until history holds one at absolute index
+// i, or the source is exhausted. It returns false once no frame at i will
+// ever be available.
+func (s *Resampler) ensure(i int) (bool, error) {
+	if i < s.base {
+		return true, nil
+	}
+	for s.base+len(s.history) <= i {
+		if s.exhausted {
+			return false, nil
+		}
+		frame, err := s.r.ReadNormalizedFloat()
+		if err == io.EOF {
+			s.exhausted = true
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		s.history = append(s.history, s.mixChannels(frame))
+	}
+	return true, nil
+}
+
+// frameAt returns the previously-ensured source frame at absolute index i.
+func (s *Resampler) frameAt(i int) []float64 {
+	return s.history[i-s.base]
+}
+
+// trim drops buffered source frames strictly before keepFrom, which are
+// never addressed again since pos only advances.
+func (s *Resampler) trim(keepFrom int) {
+	if keepFrom <= s.base {
+		return
+	}
+	drop := keepFrom - s.base
+	if drop > len(s.history) {
+		drop = len(s.history)
+	}
+	s.history = s.history[drop:]
+	s.base += drop
+}
+
+// advance moves pos forward by one output frame's worth of source frames.
+func (s *Resampler) advance() {
+	s.pos += s.srcRate / s.dstRate
+}
+
+// Read returns the next output frame, normalized to [-1, 1] and mixed to
+// the target channel count, at the target sample rate. It returns io.EOF
+// once the source Reader is exhausted and no further output frame can be
+// produced.
+func (s *Resampler) Read() ([]float64, error) {
+	if s.mode == ResampleSinc {
+		return s.readSinc()
+	}
+	return s.readLinear()
+}
+
+func (s *Resampler) readLinear() ([]float64, error) {
+	i0 := int(math.Floor(s.pos))
+	frac := s.pos - float64(i0)
+
+	ok, err := s.ensure(i0)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, io.EOF
+	}
+	f0 := s.frameAt(i0)
+
+	f1 := f0
+	if ok1, err := s.ensure(i0 + 1); err != nil {
+		return nil, err
+	} else if ok1 {
+		f1 = s.frameAt(i0 + 1)
+	}
+
+	out := make([]float64, s.dstChannels)
+	for c := range out {
+		out[c] = f0[c] + (f1[c]-f0[c])*frac
+	}
+
+	s.advance()
+	s.trim(i0)
+	return out, nil
+}
+
+func (s *Resampler) readSinc() ([]float64, error) {
+	center := s.pos
+	i0 := int(math.Floor(center))
+
+	ok, err := s.ensure(i0)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, io.EOF
+	}
+
+	lo := i0 - sincTaps + 1
+	if lo < 0 {
+		lo = 0
+	}
+	hi := i0 + sincTaps
+
+	for i := lo; i < i0; i++ {
+		if _, err := s.ensure(i); err != nil {
+			return nil, err
+		}
+	}
+
+	last := i0
+	for i := i0 + 1; i <= hi; i++ {
+		ok, err := s.ensure(i)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		last = i
+	}
+
+	out := make([]float64, s.dstChannels)
+	weightSum := 0.0
+	for i := lo; i <= last; i++ {
+		w := lanczosKernel(center-float64(i), sincTaps)
+		if w == 0 {
+			continue
+		}
+		frame := s.frameAt(i)
+		for c := range out {
+			out[c] += frame[c] * w
+		}
+		weightSum += w
+	}
+	if weightSum != 0 {
+		for c := range out {
+			out[c] /= weightSum
+		}
+	}
+
+	s.advance()
+	s.trim(i0 - sincTaps)
+	return out, nil
+}
+
+// lanczosKernel evaluates the Lanczos windowed sinc kernel of size a at x.
+func lanczosKernel(x float64, a int) float64 {
+	if x == 0 {
+		return 1
+	}
+	fa := float64(a)
+	if x < -fa || x > fa {
+		return 0
+	}
+	px := math.Pi * x
+	return fa * math.Sin(px) * math.Sin(px/fa) / (px * px)
+}
@@ -1,4 +1,20 @@
-// Package wave implements a reader and writer for standard PCM wav files.
-// It supports reading 8, 16, 24 & 32 bit integer and 32 bit float and
-// writing 8, 16, 32 bit integer and 32 bit float wave files.
+// Package wave implements a reader and writer for wav files. It supports
+// reading and writing 8, 16, 24 & 32 bit PCM, 32 & 64 bit IEEE float, and
+// A-law/mu-law companded samples, including WAVE_FORMAT_EXTENSIBLE
+// headers. The reader walks RIFF chunks rather than assuming a fixed
+// layout, so metadata chunks (LIST/INFO, bext, cue, smpl, fact, JUNK)
+// ahead of the audio data are skipped or surfaced via Reader.Chunks and
+// Reader.Metadata instead of rejecting the file.
+//
+// Reader.ReadNormalizedFloat and Resampler convert samples to a common
+// [-1, 1] amplitude range and a target sample rate/channel count, so
+// callers do not need to special-case a file's declared format.
+//
+// ReadHeader and (*Header).WriteTo/ReadFrom parse and produce a wave
+// header independently of Reader and Writer, for callers embedding
+// Header in another container format.
+//
+// Generator synthesizes silence, tones and noise on the fly, satisfying
+// the same ReadInt/ReadFloat contract as Reader, for use as a test
+// fixture or placeholder audio source without a reference file on disk.
 package wave
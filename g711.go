@@ -0,0 +1,146 @@
+package wave
+
+import "math"
+
+// Segment end tables and the bias/clip constants used by the ITU-T G.711
+// companding algorithms below follow the widely used reference
+// implementation from Sun's CCITT G.711 code (see ITU-T Recommendation
+// G.711 for the underlying specification).
+var (
+	aLawSegEnd  = [8]int16{0x1F, 0x3F, 0x7F, 0xFF, 0x1FF, 0x3FF, 0x7FF, 0xFFF}
+	muLawSegEnd = [8]int16{0x3F, 0x7F, 0xFF, 0x1FF, 0x3FF, 0x7FF, 0xFFF, 0x1FFF}
+)
+
+const (
+	muLawBias = 0x84
+	muLawClip = 8159
+)
+
+// segment returns the index of the first entry in table not smaller than
+// val, or len(table) if val exceeds every entry.
+func segment(val int16, table [8]int16) int {
+	for seg, end := range table {
+		if val <= end {
+			return seg
+		}
+	}
+	return len(table)
+}
+
+// decodeALaw expands an ITU-T G.711 A-law byte to a linear 16 bit sample.
+func decodeALaw(a byte) int16 {
+	a ^= 0x55
+	t := int16(a&0x0f) << 4
+	seg := (a & 0x70) >> 4
+	switch seg {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= seg - 1
+	}
+	if a&0x80 != 0 {
+		return t
+	}
+	return -t
+}
+
+// encodeALaw compands a linear 16 bit sample to an ITU-T G.711 A-law byte.
+func encodeALaw(pcm int16) byte {
+	pcm >>= 3
+
+	var mask byte
+	if pcm >= 0 {
+		mask = 0xD5
+	} else {
+		mask = 0x55
+		pcm = -pcm - 1
+	}
+
+	seg := segment(pcm, aLawSegEnd)
+	if seg >= 8 {
+		return 0x7F ^ mask
+	}
+
+	aval := byte(seg) << 4
+	if seg < 2 {
+		aval |= byte(pcm>>1) & 0x0F
+	} else {
+		aval |= byte(pcm>>uint(seg)) & 0x0F
+	}
+	return aval ^ mask
+}
+
+// decodeMuLaw expands an ITU-T G.711 mu-law byte to a linear 16 bit sample.
+func decodeMuLaw(u byte) int16 {
+	u = ^u
+	sign := u & 0x80
+	exponent := (u & 0x70) >> 4
+	mantissa := u & 0x0F
+
+	t := (int16(mantissa) << 3) + muLawBias
+	t <<= exponent
+
+	if sign != 0 {
+		return muLawBias - t
+	}
+	return t - muLawBias
+}
+
+// encodeMuLaw compands a linear 16 bit sample to an ITU-T G.711 mu-law byte.
+func encodeMuLaw(pcm int16) byte {
+	pcm >>= 2
+
+	var mask byte
+	if pcm < 0 {
+		pcm = -pcm
+		mask = 0x7F
+	} else {
+		mask = 0xFF
+	}
+	if pcm > muLawClip {
+		pcm = muLawClip
+	}
+	pcm += muLawBias >> 2
+
+	seg := segment(pcm, muLawSegEnd)
+	if seg >= 8 {
+		return 0x7F ^ mask
+	}
+
+	uval := byte(seg)<<4 | byte(pcm>>uint(seg+1))&0x0F
+	return uval ^ mask
+}
+
+// decodeCompanded expands an A-law or mu-law byte to a linear 16 bit
+// sample; fmtCode must be FormatALaw or FormatMULaw.
+func decodeCompanded(fmtCode uint16, b byte) int16 {
+	if fmtCode == FormatALaw {
+		return decodeALaw(b)
+	}
+	return decodeMuLaw(b)
+}
+
+// encodeCompanded compands a linear 16 bit sample to an A-law or mu-law
+// byte; fmtCode must be FormatALaw or FormatMULaw.
+func encodeCompanded(fmtCode uint16, sample int16) byte {
+	if fmtCode == FormatALaw {
+		return encodeALaw(sample)
+	}
+	return encodeMuLaw(sample)
+}
+
+// clipInt16 clamps a 64 bit sample to the int16 range that A-law and
+// mu-law companding operate on.
+func clipInt16(sample int64) int16 {
+	switch {
+	case sample > math.MaxInt16:
+		return math.MaxInt16
+	case sample < math.MinInt16:
+		return math.MinInt16
+	default:
+		return int16(sample)
+	}
+}
@@ -0,0 +1,174 @@
+package wave
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ReadHeader reads a wave header assuming the canonical fixed layout used
+// by most wave encoders: a "RIFF"/"WAVE" container with the "fmt " chunk
+// immediately followed by the "data" chunk. Unlike NewReader, it does not
+// tolerate metadata chunks ahead of "data" or interpret them; use
+// NewReader for that. ReadHeader exists as a standalone primitive so a
+// header can be parsed independently of this package's streaming
+// Reader, e.g. as one step of a larger chunked container reader, or to
+// embed Header in another container format (a FLAC-to-WAV bridge, a TTA
+// decompressor).
+func ReadHeader(r io.Reader) (Header, error) {
+	h, _, err := readHeader(r)
+	return h, err
+}
+
+// readHeader is the shared implementation behind ReadHeader and
+// (*Header).ReadFrom; it additionally reports the number of bytes
+// consumed from r, which varies with the fmt chunk's form.
+func readHeader(r io.Reader) (Header, int64, error) {
+	var h Header
+	var n int64
+
+	if _, err := io.ReadFull(r, h.RiffID[:]); err != nil {
+		return h, n, fmt.Errorf("error reading RiffID in ReadHeader: %s", err)
+	}
+	n += 4
+	if err := binary.Read(r, binary.LittleEndian, &h.DataSize); err != nil {
+		return h, n, fmt.Errorf("error reading DataSize in ReadHeader: %s", err)
+	}
+	n += 4
+	if _, err := io.ReadFull(r, h.RiffType[:]); err != nil {
+		return h, n, fmt.Errorf("error reading RiffType in ReadHeader: %s", err)
+	}
+	n += 4
+
+	id, size, err := readChunkHeader(r)
+	if err != nil {
+		return h, n, fmt.Errorf("error reading fmt chunk header in ReadHeader: %s", err)
+	}
+	n += 8
+	if !byte4Cmp(id, "fmt ") {
+		return h, n, fmt.Errorf("unexpected chunk in ReadHeader: want %q, got %q", "fmt ", id)
+	}
+	h.FmtChunkID = id
+	h.FmtChunkSize = 16
+	if err := readFmtChunk(r, size, &h); err != nil {
+		return h, n, fmt.Errorf("error reading fmt chunk in ReadHeader: %s", err)
+	}
+	n += int64(size)
+	if size%2 == 1 {
+		if err := skip(r, 1); err != nil {
+			return h, n, fmt.Errorf("error reading fmt chunk padding in ReadHeader: %s", err)
+		}
+		n++
+	}
+
+	id, size, err = readChunkHeader(r)
+	if err != nil {
+		return h, n, fmt.Errorf("error reading data chunk header in ReadHeader: %s", err)
+	}
+	n += 8
+	if !byte4Cmp(id, "data") {
+		return h, n, fmt.Errorf("unexpected chunk in ReadHeader: want %q, got %q", "data", id)
+	}
+	h.DataChunkID = id
+	h.DataChunkSize = size
+
+	if err := h.Validate(); err != nil {
+		return h, n, fmt.Errorf("could not validate header: %s", err)
+	}
+	return h, n, nil
+}
+
+// ReadFrom reads a wave header from r into h the same way ReadHeader
+// does, and satisfies io.ReaderFrom.
+func (h *Header) ReadFrom(r io.Reader) (int64, error) {
+	parsed, n, err := readHeader(r)
+	if err != nil {
+		return n, err
+	}
+	*h = parsed
+	return n, nil
+}
+
+// fmtCommon mirrors the 16 byte common portion of a "fmt " chunk, shared
+// by the standard and WAVE_FORMAT_EXTENSIBLE forms.
+type fmtCommon struct {
+	AudioFmt      uint16
+	Channels      uint16
+	SamplesPerSec uint32
+	BytesPerSec   uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// fmtExtensible mirrors the 24 bytes a WAVE_FORMAT_EXTENSIBLE "fmt " chunk
+// carries beyond fmtCommon: the cbSize extension length, then
+// ValidBitsPerSample, ChannelMask and the SubFormat GUID.
+type fmtExtensible struct {
+	CbSize             uint16
+	ValidBitsPerSample uint16
+	ChannelMask        uint32
+	SubFormat          [16]byte
+}
+
+// WriteTo writes h to w in the canonical fixed layout ReadHeader expects:
+// "RIFF"/"WAVE" followed immediately by the "fmt " chunk and the "data"
+// chunk header, with no sample data or intervening metadata chunks. The
+// "fmt " chunk is written in its 40 byte WAVE_FORMAT_EXTENSIBLE form when
+// h.AudioFmt is FormatExtensible, and in the standard 16 byte form
+// otherwise. WriteTo satisfies io.WriterTo.
+func (h *Header) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+
+	if _, err := w.Write(h.RiffID[:]); err != nil {
+		return n, fmt.Errorf("error writing RiffID in WriteTo: %s", err)
+	}
+	n += 4
+	if err := binary.Write(w, binary.LittleEndian, h.DataSize); err != nil {
+		return n, fmt.Errorf("error writing DataSize in WriteTo: %s", err)
+	}
+	n += 4
+	if _, err := w.Write(h.RiffType[:]); err != nil {
+		return n, fmt.Errorf("error writing RiffType in WriteTo: %s", err)
+	}
+	n += 4
+
+	extensible := h.AudioFmt == FormatExtensible
+	fmtSize := uint32(16)
+	if extensible {
+		fmtSize = 40
+	}
+
+	if _, err := w.Write(h.FmtChunkID[:]); err != nil {
+		return n, fmt.Errorf("error writing FmtChunkID in WriteTo: %s", err)
+	}
+	n += 4
+	if err := binary.Write(w, binary.LittleEndian, fmtSize); err != nil {
+		return n, fmt.Errorf("error writing FmtChunkSize in WriteTo: %s", err)
+	}
+	n += 4
+
+	common := fmtCommon{h.AudioFmt, h.Channels, h.SamplesPerSec, h.BytesPerSec, h.BlockAlign, h.BitsPerSample}
+	if err := binary.Write(w, binary.LittleEndian, &common); err != nil {
+		return n, fmt.Errorf("error writing fmt chunk common fields in WriteTo: %s", err)
+	}
+	n += 16
+
+	if extensible {
+		ext := fmtExtensible{22, h.ValidBitsPerSample, h.ChannelMask, h.SubFormat}
+		if err := binary.Write(w, binary.LittleEndian, &ext); err != nil {
+			return n, fmt.Errorf("error writing fmt extensible fields in WriteTo: %s", err)
+		}
+		n += 24
+	}
+
+	if _, err := w.Write(h.DataChunkID[:]); err != nil {
+		return n, fmt.Errorf("error writing DataChunkID in WriteTo: %s", err)
+	}
+	n += 4
+	if err := binary.Write(w, binary.LittleEndian, h.DataChunkSize); err != nil {
+		return n, fmt.Errorf("error writing DataChunkSize in WriteTo: %s", err)
+	}
+	n += 4
+
+	return n, nil
+}
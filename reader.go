@@ -6,6 +6,24 @@ import (
 	"io"
 )
 
+// UnknownSize is the sentinel value (0xFFFFFFFF) that streaming encoders
+// write into DataSize/DataChunkSize when the number of samples is not
+// known at the time the header is written, e.g. live capture or an
+// RF64-style container. GetSampleCount returns -1 for a header carrying
+// this value; callers should instead read samples until io.EOF.
+const UnknownSize = 0xFFFFFFFF
+
+// Recognised WAVE format tags. AudioFmt carries one of these directly,
+// except FormatExtensible, which defers to the format tag packed into the
+// "fmt " chunk's SubFormat GUID; use Header.EffectiveAudioFmt to resolve it.
+const (
+	FormatPCM        = 1      // linear PCM, BitsPerSample 8/16/24/32
+	FormatIEEEFloat  = 3      // IEEE float, BitsPerSample 32/64
+	FormatALaw       = 6      // ITU G.711 A-law, BitsPerSample 8
+	FormatMULaw      = 7      // ITU G.711 mu-law, BitsPerSample 8
+	FormatExtensible = 0xFFFE // WAVE_FORMAT_EXTENSIBLE, see SubFormat
+)
+
 // Header is a struct that holds Header information for a standard PCM Wave file.
 // The structure is used for reading & writing Wave File information.
 //
@@ -22,9 +40,26 @@ type Header struct {
 	SamplesPerSec uint32  // Sampling rate, Little Endian
 	BytesPerSec   uint32  // SampleRate * NumChannels * BitsPerSample/8, Little Endian
 	BlockAlign    uint16  // NumChannels * BitsPerSample/8, Little Endian
-	BitsPerSample uint16  // 8, 16, 24  (int) or 32 (int/float), Little Endian
+	BitsPerSample uint16  // 8, 16, 24 (int), 32 (int/float) or 64 (float), Little Endian
 	DataChunkID   [4]byte // must be "data"
 	DataChunkSize uint32  // NumSamples * NumChannels * BitsPerSample/8
+
+	// ValidBitsPerSample, ChannelMask and SubFormat are only populated
+	// when AudioFmt is FormatExtensible, i.e. the "fmt " chunk was the 40
+	// byte WAVE_FORMAT_EXTENSIBLE form.
+	ValidBitsPerSample uint16
+	ChannelMask        uint32
+	SubFormat          [16]byte // KSDATAFORMAT_SUBTYPE GUID; first 2 bytes are the real format tag
+}
+
+// EffectiveAudioFmt returns the format tag that identifies how samples are
+// encoded, resolving a FormatExtensible header to the format tag carried
+// in its SubFormat GUID.
+func (h *Header) EffectiveAudioFmt() uint16 {
+	if h.AudioFmt != FormatExtensible {
+		return h.AudioFmt
+	}
+	return binary.LittleEndian.Uint16(h.SubFormat[0:2])
 }
 
 // byte4Cmp is a utility function that compares [4]byte types with strings
@@ -41,7 +76,9 @@ func byte4Cmp(b [4]byte, s string) bool {
 	return true
 }
 
-// Validate checks wheather a wave header represents a supported PCM Wave format.
+// Validate checks wheather a wave header represents a supported Wave format:
+// PCM, IEEE float, A-law or mu-law, including when carried inside a
+// FormatExtensible header.
 func (h Header) Validate() error {
 
 	if !byte4Cmp(h.RiffID, "RIFF") {
@@ -64,23 +101,39 @@ func (h Header) Validate() error {
 		return fmt.Errorf("unexpected FmtChunkSize in header: want %d, got %d", 16, h.FmtChunkSize)
 	}
 
-	if h.DataSize != 36+h.DataChunkSize {
-		return fmt.Errorf("unexpected DataSize in header: want %d, got %d", 36+h.DataChunkSize, h.DataSize)
+	if h.DataChunkSize != UnknownSize && h.DataSize != UnknownSize && h.DataSize < 36+h.DataChunkSize {
+		return fmt.Errorf("unexpected DataSize in header: want at least %d, got %d", 36+h.DataChunkSize, h.DataSize)
 	}
 
-	if h.AudioFmt != 1 {
-		return fmt.Errorf("only uncompressed PCM formats are supported. got AudioFmt: %d", h.AudioFmt)
-	}
-	if h.BitsPerSample != 8 && h.BitsPerSample != 16 && h.BitsPerSample != 24 && h.BitsPerSample != 32 {
-		return fmt.Errorf("only 8, 16, 24 or 32 bit sampels are supported. got BitsPerSample: %d", h.BitsPerSample)
+	switch h.EffectiveAudioFmt() {
+	case FormatPCM:
+		if h.BitsPerSample != 8 && h.BitsPerSample != 16 && h.BitsPerSample != 24 && h.BitsPerSample != 32 {
+			return fmt.Errorf("only 8, 16, 24 or 32 bit samples are supported for PCM. got BitsPerSample: %d", h.BitsPerSample)
+		}
+	case FormatIEEEFloat:
+		if h.BitsPerSample != 32 && h.BitsPerSample != 64 {
+			return fmt.Errorf("only 32 or 64 bit samples are supported for IEEE float. got BitsPerSample: %d", h.BitsPerSample)
+		}
+	case FormatALaw, FormatMULaw:
+		if h.BitsPerSample != 8 {
+			return fmt.Errorf("only 8 bit samples are supported for A-law/mu-law. got BitsPerSample: %d", h.BitsPerSample)
+		}
+	default:
+		return fmt.Errorf("unsupported AudioFmt: %d", h.EffectiveAudioFmt())
 	}
 	return nil
 }
 
 // GetSampleCount calculates the number of samples int he wave file
 // using information on DataChunk size, number of channels & bit per
-// sample information contained in the wave header.
+// sample information contained in the wave header. It returns -1 if
+// the file declares an UnknownSize data chunk, which happens for
+// streaming captures whose length was not known when the header was
+// written; such files should instead be read until io.EOF.
 func (h *Header) GetSampleCount() int {
+	if h.DataChunkSize == UnknownSize {
+		return -1
+	}
 	return int(h.DataChunkSize) / int(h.Channels*(h.BitsPerSample/8))
 }
 
@@ -103,33 +156,126 @@ func (h *Header) GetSamplesPerSec() int {
 type Reader struct {
 	R io.Reader
 	H Header
+
+	// Metadata holds descriptive text and broadcast extension information
+	// recovered from any INFO or bext chunk encountered ahead of "data".
+	Metadata Metadata
+
+	chunks []Chunk
+}
+
+// Chunks returns every RIFF chunk NewReader walked over while looking for
+// "fmt " and "data", in file order, including chunks it does not otherwise
+// interpret. The "data" chunk, once found, is always last.
+func (r *Reader) Chunks() []Chunk {
+	return r.chunks
 }
 
 // NewReader creates a new wave reader encapsulating the provided io.Reader.
-// When `NewReader()` is called to create a `Reader`, it attempts to read the header information
-// from the provided reader and validates if it is a supported format. Samples can then be
-// read using `ReadInt()` or `ReadFloat()` functions depending on whether the data is expected
-// to be integer or floating point.
+// When `NewReader()` is called to create a `Reader`, it walks the RIFF
+// chunks in the file looking for "fmt " and "data", either of which may be
+// preceded by metadata chunks such as "LIST"/INFO, "bext", "cue ",
+// "smpl", "fact" or "JUNK", in any order. The walk stops at "data", so a
+// file whose "fmt " chunk comes after "data" is rejected. Chunks it does
+// not interpret are skipped rather than rejected, so files produced by real-world tools
+// (DAWs, ffmpeg, BWF capture software) that carry metadata ahead of the
+// audio data can still be opened. Recognised metadata is exposed via
+// Metadata, and every chunk encountered is available via Chunks(). Once
+// "data" is found the core PCM fields are validated with Header.Validate,
+// and samples can be read using ReadInt() or ReadFloat().
 func NewReader(r io.Reader) (*Reader, error) {
 	h := Header{}
-	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
-		return nil, fmt.Errorf("error decoding Header in NewReader:%s", err)
+	if _, err := io.ReadFull(r, h.RiffID[:]); err != nil {
+		return nil, fmt.Errorf("error reading RiffID in NewReader: %s", err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &h.DataSize); err != nil {
+		return nil, fmt.Errorf("error reading DataSize in NewReader: %s", err)
+	}
+	if _, err := io.ReadFull(r, h.RiffType[:]); err != nil {
+		return nil, fmt.Errorf("error reading RiffType in NewReader: %s", err)
+	}
+
+	var chunks []Chunk
+	var meta Metadata
+	var fmtSeen bool
+
+	for {
+		id, size, err := readChunkHeader(r)
+		if err != nil {
+			return nil, fmt.Errorf("error reading chunk header in NewReader: %s", err)
+		}
+		chunks = append(chunks, Chunk{ID: id, Size: size})
+
+		if byte4Cmp(id, "data") {
+			h.DataChunkID = id
+			h.DataChunkSize = size
+			break
+		}
+
+		var chunkErr error
+		switch {
+		case byte4Cmp(id, "fmt "):
+			if chunkErr = readFmtChunk(r, size, &h); chunkErr == nil {
+				h.FmtChunkID = id
+				h.FmtChunkSize = 16
+				fmtSeen = true
+			}
+		case byte4Cmp(id, "LIST"):
+			chunkErr = readListChunk(r, size, &meta)
+		case byte4Cmp(id, "bext"):
+			var bext *BroadcastExt
+			if bext, chunkErr = readBextChunk(r, size); chunkErr == nil {
+				meta.Broadcast = bext
+			}
+		default:
+			// cue, smpl, fact, JUNK and anything else we don't interpret
+			// are simply skipped so unfamiliar chunks never fail the parse.
+			chunkErr = skip(r, size)
+		}
+		if chunkErr != nil {
+			return nil, fmt.Errorf("error reading %s chunk in NewReader: %s", id, chunkErr)
+		}
+
+		if size%2 == 1 {
+			if err := skip(r, 1); err != nil {
+				return nil, fmt.Errorf("error reading chunk padding in NewReader: %s", err)
+			}
+		}
+	}
+
+	if !fmtSeen {
+		return nil, fmt.Errorf("no fmt chunk found before data chunk in NewReader")
 	}
 	if err := h.Validate(); err != nil {
 		return nil, fmt.Errorf("could not validate header: %s", err)
 	}
-	return &Reader{r, h}, nil
+
+	return &Reader{R: r, H: h, Metadata: meta, chunks: chunks}, nil
 }
 
 // ReadInt reads the data from the wave file as an integer. When reading data, the function respects
 // the Bits Per Sample declared in the wave file header. The read functions return a `[]int64`
 // where each slice element corresponds to the sample for a channel. The 64 bit types are meant
-// to allow headroom for any further audio processing without clipping. The read data is simply
-// cast into 64 bit integers and no other normalization or conversion is performed.
+// to allow headroom for any further audio processing without clipping. For PCM data, the read
+// data is simply cast into 64 bit integers; A-law and mu-law data is expanded to linear samples
+// via the standard ITU G.711 tables. No other normalization or conversion is performed.
 func (r *Reader) ReadInt() ([]int64, error) {
+	fmtCode := r.H.EffectiveAudioFmt()
+	if fmtCode == FormatIEEEFloat {
+		return nil, fmt.Errorf("cannot ReadInt from an IEEE float wave file: use ReadFloat")
+	}
 
 	ret := make([]int64, r.H.Channels)
 	for j := 0; j < int(r.H.Channels); j++ {
+		if fmtCode == FormatALaw || fmtCode == FormatMULaw {
+			var data byte
+			if err := binary.Read(r.R, binary.LittleEndian, &data); err != nil {
+				return nil, err
+			}
+			ret[j] = int64(decodeCompanded(fmtCode, data))
+			continue
+		}
+
 		switch r.H.BitsPerSample {
 		case 8:
 			var data int8
@@ -167,25 +313,32 @@ func (r *Reader) ReadInt() ([]int64, error) {
 	return ret, nil
 }
 
-// ReadFloat reads the data from the wave file as an integer. When reading data, the function respects
-// the Bits Per Sample declared in the wave file header. The read functions return a `[]float64`
-// where each slice element corresponds to the sample for a channel. The 64 bit types are meant
-// to allow headroom for any further audio processing without clipping. The read data is simply
-// cast into 64 bit float and no other normalization or conversion is performed.
+// ReadFloat reads the data from the wave file as a float. When reading data, the function respects
+// the Bits Per Sample declared in the wave file header, which must be 32 or 64 for IEEE float data.
+// The read functions return a `[]float64` where each slice element corresponds to the sample for
+// a channel. The read data is simply cast into 64 bit float and no other normalization or
+// conversion is performed.
 func (r *Reader) ReadFloat() ([]float64, error) {
-	if r.H.BitsPerSample != 32 {
-		return nil, fmt.Errorf("unexpected BitsPerSample in ReadRawFloat: want 32, got %d", r.H.BitsPerSample)
-	}
-
-	data := make([]float32, r.H.Channels)
-
-	if err := binary.Read(r.R, binary.LittleEndian, data); err != nil {
-		return nil, fmt.Errorf("error reading data in ReadRawFloat: %s", err)
+	if r.H.EffectiveAudioFmt() != FormatIEEEFloat {
+		return nil, fmt.Errorf("cannot ReadFloat from a non IEEE float wave file: AudioFmt %d", r.H.EffectiveAudioFmt())
 	}
 
 	ret := make([]float64, r.H.Channels)
-	for j, item := range data {
-		ret[j] = float64(item)
+	switch r.H.BitsPerSample {
+	case 32:
+		data := make([]float32, r.H.Channels)
+		if err := binary.Read(r.R, binary.LittleEndian, data); err != nil {
+			return nil, fmt.Errorf("error reading data in ReadFloat: %s", err)
+		}
+		for j, item := range data {
+			ret[j] = float64(item)
+		}
+	case 64:
+		if err := binary.Read(r.R, binary.LittleEndian, ret); err != nil {
+			return nil, fmt.Errorf("error reading data in ReadFloat: %s", err)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected BitsPerSample in ReadFloat: want 32 or 64, got %d", r.H.BitsPerSample)
 	}
 	return ret, nil
 }
@@ -2,8 +2,12 @@ package wave
 
 import (
 	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"math"
 	"testing"
+	"time"
 )
 
 // These characteristics describe the wave test data sample
@@ -23,19 +27,19 @@ func TestReadHeader(t *testing.T) {
 		t.Fatalf("Error reading test data header: %s", err)
 	}
 
-	if numSamples := r.GetSampleCount(); numSamples != testSampleCount {
+	if numSamples := r.H.GetSampleCount(); numSamples != testSampleCount {
 		t.Fatalf("Sample count mismatch: want %d: got %d", testSampleCount, numSamples)
 	} else {
 		t.Logf("Sample Count: %d", numSamples)
 	}
 
-	if channels := r.GetChannels(); channels != testChannels {
+	if channels := r.H.GetChannels(); channels != testChannels {
 		t.Fatalf("Channel  mismatch: want %d: got %d", testChannels, channels)
 	} else {
 		t.Logf("Channels: %d", channels)
 	}
 
-	if bitsPerSample := r.GetBitsPerSample(); bitsPerSample != testBitsPerSample {
+	if bitsPerSample := r.H.GetBitsPerSample(); bitsPerSample != testBitsPerSample {
 		t.Fatalf("Channel  mismatch: want %d: got %d", testBitsPerSample, bitsPerSample)
 	} else {
 		t.Logf("bits per sample: %d", bitsPerSample)
@@ -52,7 +56,7 @@ func TestReadData(t *testing.T) {
 	// count the number of samples where absolute sample value is +/- 0.02
 	// and check whether these are at least 90% of the samples
 	ctr := 0
-	for j := 0; j < r.GetSampleCount(); j++ {
+	for j := 0; j < r.H.GetSampleCount(); j++ {
 		sample, err := r.ReadInt()
 		if err != nil {
 			t.Fatal(err)
@@ -67,7 +71,7 @@ func TestReadData(t *testing.T) {
 		}
 	}
 
-	ratio := float64(ctr) / float64(r.GetSampleCount())
+	ratio := float64(ctr) / float64(r.H.GetSampleCount())
 	if ratio < 0.9 {
 		t.Fatalf("unexpected low amplitudes : want %f, got %f", 0.9, ratio)
 	} else {
@@ -75,15 +79,671 @@ func TestReadData(t *testing.T) {
 	}
 }
 
+// writeInfoChunk appends a word-aligned RIFF INFO sub-chunk to buf.
+func writeInfoChunk(buf *bytes.Buffer, id, text string) {
+	buf.WriteString(id)
+	binary.Write(buf, binary.LittleEndian, uint32(len(text)))
+	buf.WriteString(text)
+	if len(text)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+func TestReadHeaderWithMetadataAndUnknownChunks(t *testing.T) {
+	samples := []int16{100, -100, 200, -200}
+	sampleBytes := make([]byte, len(samples)*2)
+	for j, s := range samples {
+		binary.LittleEndian.PutUint16(sampleBytes[j*2:], uint16(s))
+	}
+
+	fmtBody := new(bytes.Buffer)
+	binary.Write(fmtBody, binary.LittleEndian, struct {
+		AudioFmt      uint16
+		Channels      uint16
+		SamplesPerSec uint32
+		BytesPerSec   uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+	}{1, 1, 8000, 16000, 2, 16})
+
+	info := new(bytes.Buffer)
+	info.WriteString("INFO")
+	writeInfoChunk(info, "INAM", "Test Title")
+	writeInfoChunk(info, "IART", "Test Artist")
+
+	list := new(bytes.Buffer)
+	list.WriteString("LIST")
+	binary.Write(list, binary.LittleEndian, uint32(info.Len()))
+	list.Write(info.Bytes())
+
+	junk := new(bytes.Buffer)
+	junk.WriteString("JUNK")
+	binary.Write(junk, binary.LittleEndian, uint32(4))
+	junk.Write([]byte{0, 0, 0, 0})
+
+	fmtChunk := new(bytes.Buffer)
+	fmtChunk.WriteString("fmt ")
+	binary.Write(fmtChunk, binary.LittleEndian, uint32(fmtBody.Len()))
+	fmtChunk.Write(fmtBody.Bytes())
+
+	dataChunk := new(bytes.Buffer)
+	dataChunk.WriteString("data")
+	binary.Write(dataChunk, binary.LittleEndian, uint32(len(sampleBytes)))
+	dataChunk.Write(sampleBytes)
+
+	body := new(bytes.Buffer)
+	body.WriteString("WAVE")
+	body.Write(list.Bytes())
+	body.Write(junk.Bytes())
+	body.Write(fmtChunk.Bytes())
+	body.Write(dataChunk.Bytes())
+
+	riff := new(bytes.Buffer)
+	riff.WriteString("RIFF")
+	binary.Write(riff, binary.LittleEndian, uint32(body.Len()))
+	riff.Write(body.Bytes())
+
+	r, err := NewReader(riff)
+	if err != nil {
+		t.Fatalf("Error reading wave with metadata: %s", err)
+	}
+
+	if r.Metadata.Title != "Test Title" {
+		t.Fatalf("Title mismatch: want %q, got %q", "Test Title", r.Metadata.Title)
+	}
+	if r.Metadata.Artist != "Test Artist" {
+		t.Fatalf("Artist mismatch: want %q, got %q", "Test Artist", r.Metadata.Artist)
+	}
+
+	chunks := r.Chunks()
+	if len(chunks) != 4 {
+		t.Fatalf("Chunk count mismatch: want %d, got %d", 4, len(chunks))
+	}
+	if chunks[len(chunks)-1].String() != "data" {
+		t.Fatalf("last chunk should be data, got %s", chunks[len(chunks)-1])
+	}
+
+	for j, want := range samples {
+		sample, err := r.ReadInt()
+		if err != nil {
+			t.Fatalf("Error reading sample %d: %s", j, err)
+		}
+		if sample[0] != int64(want) {
+			t.Fatalf("Sample mismatch at %d: want %d, got %d", j, want, sample[0])
+		}
+	}
+}
+
+func TestReadHeaderRejectsOversizedInfoSubChunk(t *testing.T) {
+	info := new(bytes.Buffer)
+	info.WriteString("INFO")
+	info.WriteString("INAM")
+	binary.Write(info, binary.LittleEndian, uint32(0xFFFFFFF0))
+
+	list := new(bytes.Buffer)
+	list.WriteString("LIST")
+	binary.Write(list, binary.LittleEndian, uint32(info.Len()))
+	list.Write(info.Bytes())
+
+	body := new(bytes.Buffer)
+	body.WriteString("WAVE")
+	body.Write(list.Bytes())
+
+	riff := new(bytes.Buffer)
+	riff.WriteString("RIFF")
+	binary.Write(riff, binary.LittleEndian, uint32(body.Len()))
+	riff.Write(body.Bytes())
+
+	if _, err := NewReader(riff); err == nil {
+		t.Fatal("expected NewReader to reject an INFO sub-chunk size exceeding its LIST chunk, got nil error")
+	}
+}
+
+func TestReadHeaderSkipsTrailingJunkInInfoList(t *testing.T) {
+	info := new(bytes.Buffer)
+	info.WriteString("INFO")
+	writeInfoChunk(info, "INAM", "Test Title")
+	info.Write([]byte{0, 0, 0}) // trailing bytes that don't form a full sub-chunk header
+
+	list := new(bytes.Buffer)
+	list.WriteString("LIST")
+	binary.Write(list, binary.LittleEndian, uint32(info.Len()))
+	list.Write(info.Bytes())
+	if info.Len()%2 == 1 {
+		list.WriteByte(0) // RIFF pad byte for the odd-sized LIST chunk
+	}
+
+	fmtBody := new(bytes.Buffer)
+	binary.Write(fmtBody, binary.LittleEndian, struct {
+		AudioFmt      uint16
+		Channels      uint16
+		SamplesPerSec uint32
+		BytesPerSec   uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+	}{1, 1, 8000, 16000, 2, 16})
+
+	fmtChunk := new(bytes.Buffer)
+	fmtChunk.WriteString("fmt ")
+	binary.Write(fmtChunk, binary.LittleEndian, uint32(fmtBody.Len()))
+	fmtChunk.Write(fmtBody.Bytes())
+
+	dataChunk := new(bytes.Buffer)
+	dataChunk.WriteString("data")
+	binary.Write(dataChunk, binary.LittleEndian, uint32(0))
+
+	body := new(bytes.Buffer)
+	body.WriteString("WAVE")
+	body.Write(list.Bytes())
+	body.Write(fmtChunk.Bytes())
+	body.Write(dataChunk.Bytes())
+
+	riff := new(bytes.Buffer)
+	riff.WriteString("RIFF")
+	binary.Write(riff, binary.LittleEndian, uint32(body.Len()))
+	riff.Write(body.Bytes())
+
+	r, err := NewReader(riff)
+	if err != nil {
+		t.Fatalf("Error reading wave with trailing junk in INFO list: %s", err)
+	}
+	if r.Metadata.Title != "Test Title" {
+		t.Fatalf("Title mismatch: want %q, got %q", "Test Title", r.Metadata.Title)
+	}
+	if r.H.SamplesPerSec != 8000 {
+		t.Fatalf("fmt chunk desynced: want SamplesPerSec 8000, got %d", r.H.SamplesPerSec)
+	}
+}
+
+func TestCompandedRoundTrip(t *testing.T) {
+	samples := []int64{0, 10000, -10000, 30000}
+
+	for _, format := range []uint16{FormatALaw, FormatMULaw} {
+		buf := &bytes.Buffer{}
+		w, err := NewWriterFmt(buf, 1, 8000, 8, len(samples), format)
+		if err != nil {
+			t.Fatalf("Error creating companded writer for format %d: %s", format, err)
+		}
+		for _, s := range samples {
+			if err := w.WriteInt([]int64{s}); err != nil {
+				t.Fatalf("Error writing companded sample: %s", err)
+			}
+		}
+
+		r, err := NewReader(buf)
+		if err != nil {
+			t.Fatalf("Error reading companded wave for format %d: %s", format, err)
+		}
+		if got := r.H.EffectiveAudioFmt(); got != format {
+			t.Fatalf("EffectiveAudioFmt mismatch: want %d, got %d", format, got)
+		}
+
+		for j, want := range samples {
+			got, err := r.ReadInt()
+			if err != nil {
+				t.Fatalf("Error reading companded sample %d: %s", j, err)
+			}
+			// companding is lossy, so allow a generous tolerance on the round trip
+			if diff := math.Abs(float64(got[0] - want)); diff > 2000 {
+				t.Fatalf("companded round trip mismatch at %d: want ~%d, got %d", j, want, got[0])
+			}
+		}
+	}
+}
+
+// seekBuffer is a minimal in-memory io.WriteSeeker, standing in for a real
+// file when exercising NewStreamWriter's back-patching of Close.
+type seekBuffer struct {
+	buf []byte
+	pos int
+}
+
+func (s *seekBuffer) Write(p []byte) (int, error) {
+	if s.pos+len(p) > len(s.buf) {
+		grown := make([]byte, s.pos+len(p))
+		copy(grown, s.buf)
+		s.buf = grown
+	}
+	n := copy(s.buf[s.pos:], p)
+	s.pos += n
+	return n, nil
+}
+
+func (s *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart {
+		return 0, fmt.Errorf("seekBuffer only supports io.SeekStart")
+	}
+	s.pos = int(offset)
+	return offset, nil
+}
+
+func TestStreamWriterBackpatchesSizes(t *testing.T) {
+	samples := [][]int64{{100, -100}, {200, -200}, {300, -300}}
+
+	sb := &seekBuffer{}
+	w, err := NewStreamWriter(sb, testChannels, testSamplesPerSec, testBitsPerSample)
+	if err != nil {
+		t.Fatalf("Error creating stream writer: %s", err)
+	}
+	for _, s := range samples {
+		if err := w.WriteInt(s); err != nil {
+			t.Fatalf("Error writing sample: %s", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Error closing stream writer: %s", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(sb.buf))
+	if err != nil {
+		t.Fatalf("Error reading back stream written wave: %s", err)
+	}
+	if got := r.H.GetSampleCount(); got != len(samples) {
+		t.Fatalf("Sample count mismatch after Close: want %d, got %d", len(samples), got)
+	}
+	for j, want := range samples {
+		got, err := r.ReadInt()
+		if err != nil {
+			t.Fatalf("Error reading sample %d: %s", j, err)
+		}
+		if got[0] != want[0] || got[1] != want[1] {
+			t.Fatalf("Sample mismatch at %d: want %v, got %v", j, want, got)
+		}
+	}
+}
+
+func TestStreamWriterRF64WritesPlaceholderSizes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewStreamWriterRF64(buf, testChannels, testSamplesPerSec, testBitsPerSample)
+	if err != nil {
+		t.Fatalf("Error creating RF64 stream writer: %s", err)
+	}
+	if err := w.WriteInt([]int64{1, -1}); err != nil {
+		t.Fatalf("Error writing sample: %s", err)
+	}
+
+	got := buf.Bytes()
+	if string(got[0:4]) != "RIFF" || string(got[8:12]) != "WAVE" || string(got[12:16]) != "ds64" {
+		t.Fatalf("unexpected RF64 header layout: %v", got[0:16])
+	}
+	if riffSize := binary.LittleEndian.Uint32(got[4:8]); riffSize != UnknownSize {
+		t.Fatalf("RIFF DataSize mismatch: want %#x, got %#x", uint32(UnknownSize), riffSize)
+	}
+
+	// Close cannot patch a plain io.Writer, so it must be a documented no-op.
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close on a non-seekable RF64 writer should be a no-op, got: %s", err)
+	}
+}
+
+func TestReadNormalizedFloat(t *testing.T) {
+	r, err := NewReader(bytes.NewReader(testData))
+	if err != nil {
+		t.Fatalf("Error creating reader: %s", err)
+	}
+
+	for j := 0; j < r.H.GetSampleCount(); j++ {
+		sample, err := r.ReadNormalizedFloat()
+		if err != nil {
+			t.Fatalf("Error reading normalized sample %d: %s", j, err)
+		}
+		for c, v := range sample {
+			if v < -1 || v > 1 {
+				t.Fatalf("normalized sample %d channel %d out of range: %f", j, c, v)
+			}
+		}
+	}
+}
+
+func newMonoPCMReader(t *testing.T, samples []int64, samplesPerSec int) *Reader {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, 1, samplesPerSec, 16, len(samples))
+	if err != nil {
+		t.Fatalf("Error creating writer: %s", err)
+	}
+	for _, s := range samples {
+		if err := w.WriteInt([]int64{s}); err != nil {
+			t.Fatalf("Error writing sample: %s", err)
+		}
+	}
+
+	r, err := NewReader(buf)
+	if err != nil {
+		t.Fatalf("Error creating reader: %s", err)
+	}
+	return r
+}
+
+func TestResamplerIdentityRateIsPassthrough(t *testing.T) {
+	samples := []int64{1000, -2000, 3000, -4000}
+	r := newMonoPCMReader(t, samples, 8000)
+
+	resampler, err := NewResampler(r, 8000, 1, ResampleLinear)
+	if err != nil {
+		t.Fatalf("Error creating resampler: %s", err)
+	}
+
+	for j, want := range samples {
+		got, err := resampler.Read()
+		if err != nil {
+			t.Fatalf("Error reading resampled frame %d: %s", j, err)
+		}
+		wantNorm := float64(want) / 32768.0
+		if diff := math.Abs(got[0] - wantNorm); diff > 1e-9 {
+			t.Fatalf("frame %d mismatch: want %f, got %f", j, wantNorm, got[0])
+		}
+	}
+}
+
+func TestResamplerUpmixesMonoToStereo(t *testing.T) {
+	samples := []int64{1000, -1000}
+	r := newMonoPCMReader(t, samples, 8000)
+
+	resampler, err := NewResampler(r, 8000, 2, ResampleLinear)
+	if err != nil {
+		t.Fatalf("Error creating resampler: %s", err)
+	}
+
+	for j := range samples {
+		got, err := resampler.Read()
+		if err != nil {
+			t.Fatalf("Error reading resampled frame %d: %s", j, err)
+		}
+		if got[0] != got[1] {
+			t.Fatalf("frame %d: expected duplicated channels, got %v", j, got)
+		}
+	}
+}
+
+func TestResamplerHalvesSampleRate(t *testing.T) {
+	samples := []int64{0, 16384, 0, -16384, 0, 16384, 0, -16384}
+	r := newMonoPCMReader(t, samples, 8000)
+
+	resampler, err := NewResampler(r, 4000, 1, ResampleLinear)
+	if err != nil {
+		t.Fatalf("Error creating resampler: %s", err)
+	}
+
+	ctr := 0
+	for {
+		if _, err := resampler.Read(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatalf("Error reading resampled frame %d: %s", ctr, err)
+		}
+		ctr++
+	}
+
+	if ctr != len(samples)/2 {
+		t.Fatalf("resampled frame count mismatch: want %d, got %d", len(samples)/2, ctr)
+	}
+}
+
+func TestResamplerSincIdentityRateStopsAtEOF(t *testing.T) {
+	samples := make([]int64, 20)
+	for j := range samples {
+		samples[j] = int64(1000 * (j%2*2 - 1))
+	}
+	r := newMonoPCMReader(t, samples, 8000)
+
+	resampler, err := NewResampler(r, 8000, 1, ResampleSinc)
+	if err != nil {
+		t.Fatalf("Error creating resampler: %s", err)
+	}
+
+	ctr := 0
+	for {
+		got, err := resampler.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatalf("Error reading resampled frame %d: %s", ctr, err)
+		}
+		if got[0] < -1 || got[0] > 1 {
+			t.Fatalf("resampled frame %d out of range: %f", ctr, got[0])
+		}
+		ctr++
+	}
+
+	if ctr != len(samples) {
+		t.Fatalf("resampled frame count mismatch: want %d, got %d", len(samples), ctr)
+	}
+}
+
+func TestIntBlockRoundTrip(t *testing.T) {
+	frames := [][]int64{{100, -100}, {200, -200}, {24000, -24000}}
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, testChannels, testSamplesPerSec, testBitsPerSample, len(frames))
+	if err != nil {
+		t.Fatalf("Error creating writer: %s", err)
+	}
+	if err := w.WriteIntBlock(frames); err != nil {
+		t.Fatalf("Error in WriteIntBlock: %s", err)
+	}
+
+	r, err := NewReader(buf)
+	if err != nil {
+		t.Fatalf("Error creating reader: %s", err)
+	}
+
+	got := make([][]int64, len(frames))
+	n, err := r.ReadIntBlock(got, len(frames))
+	if err != nil {
+		t.Fatalf("Error in ReadIntBlock: %s", err)
+	}
+	if n != len(frames) {
+		t.Fatalf("frame count mismatch: want %d, got %d", len(frames), n)
+	}
+	for j, want := range frames {
+		if got[j][0] != want[0] || got[j][1] != want[1] {
+			t.Fatalf("frame %d mismatch: want %v, got %v", j, want, got[j])
+		}
+	}
+}
+
+func TestFloatBlockRoundTrip(t *testing.T) {
+	frames := [][]float64{{0.5, -0.5}, {0.25, -0.25}}
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriterFmt(buf, testChannels, testSamplesPerSec, 32, len(frames), FormatIEEEFloat)
+	if err != nil {
+		t.Fatalf("Error creating writer: %s", err)
+	}
+	if err := w.WriteFloatBlock(frames); err != nil {
+		t.Fatalf("Error in WriteFloatBlock: %s", err)
+	}
+
+	r, err := NewReader(buf)
+	if err != nil {
+		t.Fatalf("Error creating reader: %s", err)
+	}
+
+	got := make([][]float64, len(frames))
+	n, err := r.ReadFloatBlock(got, len(frames))
+	if err != nil {
+		t.Fatalf("Error in ReadFloatBlock: %s", err)
+	}
+	if n != len(frames) {
+		t.Fatalf("frame count mismatch: want %d, got %d", len(frames), n)
+	}
+	for j, want := range frames {
+		if got[j][0] != want[0] || got[j][1] != want[1] {
+			t.Fatalf("frame %d mismatch: want %v, got %v", j, want, got[j])
+		}
+	}
+}
+
+// benchmarkWaveBytes is a 2 minute stereo 16 bit 44.1 kHz wave file's worth
+// of raw sample data, used to benchmark block vs per-sample I/O.
+func benchmarkWaveBytes() []byte {
+	frames := testSamplesPerSec * 120
+	buf := make([]byte, frames*testChannels*2)
+	for j := range buf {
+		buf[j] = byte(j)
+	}
+	return buf
+}
+
+func BenchmarkReadIntPerSample(b *testing.B) {
+	data := benchmarkWaveBytes()
+
+	for i := 0; i < b.N; i++ {
+		r := &Reader{R: bytes.NewReader(data), H: Header{Channels: testChannels, BitsPerSample: testBitsPerSample, AudioFmt: FormatPCM}}
+		for {
+			if _, err := r.ReadInt(); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkReadIntBlock(b *testing.B) {
+	data := benchmarkWaveBytes()
+	frames := len(data) / (testChannels * 2)
+
+	for i := 0; i < b.N; i++ {
+		r := &Reader{R: bytes.NewReader(data), H: Header{Channels: testChannels, BitsPerSample: testBitsPerSample, AudioFmt: FormatPCM}}
+		dst := make([][]int64, frames)
+		if _, err := r.ReadIntBlock(dst, frames); err != nil && err != io.EOF {
+			b.Fatalf("Error in ReadIntBlock: %s", err)
+		}
+	}
+}
+
+func TestReadHeaderAndWriteToRoundTrip(t *testing.T) {
+	h, err := ReadHeader(bytes.NewReader(testData))
+	if err != nil {
+		t.Fatalf("Error in ReadHeader: %s", err)
+	}
+	if got := h.GetChannels(); got != testChannels {
+		t.Fatalf("Channels mismatch: want %d, got %d", testChannels, got)
+	}
+	if got := h.GetSampleCount(); got != testSampleCount {
+		t.Fatalf("Sample count mismatch: want %d, got %d", testSampleCount, got)
+	}
+
+	buf := &bytes.Buffer{}
+	n, err := h.WriteTo(buf)
+	if err != nil {
+		t.Fatalf("Error in WriteTo: %s", err)
+	}
+	if int(n) != len(testData)-testSampleCount*testChannels*2 {
+		t.Fatalf("WriteTo byte count mismatch: want %d, got %d", len(testData)-testSampleCount*testChannels*2, n)
+	}
+	if !bytes.Equal(buf.Bytes(), testData[:n]) {
+		t.Fatalf("WriteTo output mismatch:\nwant %v\ngot  %v", testData[:n], buf.Bytes())
+	}
+
+	var h2 Header
+	if _, err := h2.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Error in ReadFrom: %s", err)
+	}
+	if h2 != h {
+		t.Fatalf("ReadFrom round trip mismatch:\nwant %+v\ngot  %+v", h, h2)
+	}
+}
+
+func TestGeneratorSilenceProducesZeroesForDuration(t *testing.T) {
+	g := NewSilence(2, 8000, 16, 1*time.Millisecond)
+
+	ctr := 0
+	for {
+		sample, err := g.ReadInt()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatalf("Error reading silence frame %d: %s", ctr, err)
+		}
+		if sample[0] != 0 || sample[1] != 0 {
+			t.Fatalf("frame %d: expected silence, got %v", ctr, sample)
+		}
+		ctr++
+	}
+
+	if want := 8; ctr != want {
+		t.Fatalf("frame count mismatch: want %d, got %d", want, ctr)
+	}
+}
+
+func TestGeneratorTonePeaksAtExpectedAmplitude(t *testing.T) {
+	g := NewTone(1, 8000, 16, 1*time.Millisecond, 1000, 0.5, Sine)
+
+	peak := 0.0
+	for {
+		sample, err := g.ReadFloat()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatalf("Error reading tone frame: %s", err)
+		}
+		if math.Abs(sample[0]) > peak {
+			peak = math.Abs(sample[0])
+		}
+		if sample[0] < -0.5-1e-9 || sample[0] > 0.5+1e-9 {
+			t.Fatalf("tone sample %f exceeds amplitude 0.5", sample[0])
+		}
+	}
+	if peak < 0.4 {
+		t.Fatalf("tone peak amplitude too low: got %f, want close to 0.5", peak)
+	}
+}
+
+func TestGeneratorNoiseStaysWithinAmplitude(t *testing.T) {
+	for _, kind := range []NoiseKind{WhiteNoise, PinkNoise} {
+		g := NewNoise(1, 8000, 16, 5*time.Millisecond, 0.5, kind)
+		for {
+			sample, err := g.ReadFloat()
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				t.Fatalf("Error reading noise frame: %s", err)
+			}
+			if math.Abs(sample[0]) > 0.5+1e-9 {
+				t.Fatalf("noise kind %d sample %f exceeds amplitude 0.5", kind, sample[0])
+			}
+		}
+	}
+}
+
+func TestWriteIntRejectsExtensibleFloatHeader(t *testing.T) {
+	h := Header{
+		RiffID:        [4]byte{'R', 'I', 'F', 'F'},
+		RiffType:      [4]byte{'W', 'A', 'V', 'E'},
+		FmtChunkID:    [4]byte{'f', 'm', 't', ' '},
+		FmtChunkSize:  16,
+		AudioFmt:      FormatExtensible,
+		Channels:      1,
+		SamplesPerSec: 8000,
+		BitsPerSample: 32,
+		DataChunkID:   [4]byte{'d', 'a', 't', 'a'},
+	}
+	binary.LittleEndian.PutUint16(h.SubFormat[0:2], FormatIEEEFloat)
+
+	w := &Writer{W: &bytes.Buffer{}, H: h, numSamples: -1}
+	if err := w.WriteInt([]int64{1}); err == nil {
+		t.Fatalf("WriteInt on an extensible IEEE float header should be rejected, got nil error")
+	}
+	if err := w.WriteIntBlock([][]int64{{1}}); err == nil {
+		t.Fatalf("WriteIntBlock on an extensible IEEE float header should be rejected, got nil error")
+	}
+}
+
 func TestWriteData(t *testing.T) {
 	r, err := NewReader(bytes.NewReader(testData))
 	if err != nil {
 		t.Fatalf("Error: creating reader in TestWriteData:%s", err)
 	}
 
-	samples := make([][]int64, r.GetSampleCount())
+	samples := make([][]int64, r.H.GetSampleCount())
 
-	for j := 0; j < r.GetSampleCount(); j++ {
+	for j := 0; j < r.H.GetSampleCount(); j++ {
 		sample, err := r.ReadInt()
 		if err != nil {
 			t.Fatalf("Error: reading testdata in TestWriteData: %s", err)
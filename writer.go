@@ -7,18 +7,30 @@ import (
 )
 
 // Writer creates a writer for wave files encapsulating an io.Writer.
-// It supports 8, 16 and 32 bit integer and 32 bit float formats.
+// It supports 8, 16 and 32 bit PCM (and 24 bit PCM through WriteIntBlock),
+// 32 and 64 bit IEEE float, and A-law/mu-law companded formats.
 type Writer struct {
 	W          io.Writer
 	H          Header
 	ctr        int
-	numSamples int
+	numSamples int // -1 for a Writer created by NewStreamWriter, whose final sample count is not yet known
+
+	seeker io.WriteSeeker // non-nil only for a Writer created by NewStreamWriter, used by Close to backpatch sizes
 }
 
 // NewWriter creates a wave writer encapsulating a provided io.Writer
 // NewWriter() attempts to first write the wave header to the provided writer and
 // samples can be subsequently written through `WriteInt()` and `WriteFloat()` functions.
+// It always writes a FormatPCM header; use NewWriterFmt to write IEEE float, A-law
+// or mu-law data instead.
 func NewWriter(w io.Writer, channels, samplesPerSec, bitsPerSample, numSamples int) (*Writer, error) {
+	return NewWriterFmt(w, channels, samplesPerSec, bitsPerSample, numSamples, FormatPCM)
+}
+
+// NewWriterFmt is like NewWriter but lets the caller select the AudioFmt written
+// to the header: FormatPCM, FormatIEEEFloat, FormatALaw or FormatMULaw. WriteInt
+// and WriteFloat route their encoding based on this format.
+func NewWriterFmt(w io.Writer, channels, samplesPerSec, bitsPerSample, numSamples int, audioFmt uint16) (*Writer, error) {
 
 	subChunk2Size := uint32(numSamples * channels * bitsPerSample / 8)
 	h := Header{
@@ -27,7 +39,7 @@ func NewWriter(w io.Writer, channels, samplesPerSec, bitsPerSample, numSamples i
 		RiffType:      [4]byte{'W', 'A', 'V', 'E'},
 		FmtChunkID:    [4]byte{'f', 'm', 't', ' '},
 		FmtChunkSize:  16,
-		AudioFmt:      1,
+		AudioFmt:      audioFmt,
 		Channels:      uint16(channels),
 		SamplesPerSec: uint32(samplesPerSec),
 		BytesPerSec:   uint32(samplesPerSec * channels * bitsPerSample / 8),
@@ -37,30 +49,233 @@ func NewWriter(w io.Writer, channels, samplesPerSec, bitsPerSample, numSamples i
 		DataChunkSize: subChunk2Size,
 	}
 
-	if err := binary.Write(w, binary.LittleEndian, &h); err != nil {
+	if err := writeCoreHeader(w, &h); err != nil {
 		return nil, fmt.Errorf("error writing wave header in NewWriter: %s", err)
 	}
 
-	return &Writer{w, h, 0, numSamples}, nil
+	return &Writer{W: w, H: h, numSamples: numSamples}, nil
+}
+
+// NewStreamWriter creates a wave writer for a destination whose final
+// sample count is not known up front, such as a live capture, a network
+// stream, or procedurally generated audio. It writes a placeholder
+// FormatPCM header with DataSize and DataChunkSize set to UnknownSize;
+// samples are written as usual with WriteInt or WriteIntBlock (WriteFloat
+// rejects the PCM header), and Close
+// must be called once writing is finished to seek back and rewrite the
+// real sizes.
+func NewStreamWriter(w io.WriteSeeker, channels, samplesPerSec, bitsPerSample int) (*Writer, error) {
+	h := Header{
+		RiffID:        [4]byte{'R', 'I', 'F', 'F'},
+		DataSize:      UnknownSize,
+		RiffType:      [4]byte{'W', 'A', 'V', 'E'},
+		FmtChunkID:    [4]byte{'f', 'm', 't', ' '},
+		FmtChunkSize:  16,
+		AudioFmt:      FormatPCM,
+		Channels:      uint16(channels),
+		SamplesPerSec: uint32(samplesPerSec),
+		BytesPerSec:   uint32(samplesPerSec * channels * bitsPerSample / 8),
+		BlockAlign:    uint16(channels * bitsPerSample / 8),
+		BitsPerSample: uint16(bitsPerSample),
+		DataChunkID:   [4]byte{'d', 'a', 't', 'a'},
+		DataChunkSize: UnknownSize,
+	}
+
+	if err := writeCoreHeader(w, &h); err != nil {
+		return nil, fmt.Errorf("error writing wave header in NewStreamWriter: %s", err)
+	}
+
+	return &Writer{W: w, H: h, numSamples: -1, seeker: w}, nil
+}
+
+// riffDataSizeOffset and dataChunkSizeOffset are the byte offsets of the
+// RIFF DataSize and DataChunkSize fields within the 44 byte core header
+// written by writeCoreHeader.
+const (
+	riffDataSizeOffset  = 4
+	dataChunkSizeOffset = 40
+)
+
+// Close finalizes a wave file written with NewStreamWriter: it seeks back
+// to the header and rewrites DataSize and DataChunkSize with the real
+// sizes now that the final sample count is known. It is a no-op for a
+// Writer created with NewWriter or NewWriterFmt, which already knew their
+// sample count up front and need no finalizing.
+func (w *Writer) Close() error {
+	if w.seeker == nil {
+		return nil
+	}
+
+	dataChunkSize := uint32(w.ctr * int(w.H.Channels) * int(w.H.BitsPerSample) / 8)
+
+	if _, err := w.seeker.Seek(riffDataSizeOffset, io.SeekStart); err != nil {
+		return fmt.Errorf("error seeking to RIFF DataSize in Close: %s", err)
+	}
+	if err := binary.Write(w.seeker, binary.LittleEndian, 36+dataChunkSize); err != nil {
+		return fmt.Errorf("error rewriting RIFF DataSize in Close: %s", err)
+	}
+
+	if _, err := w.seeker.Seek(dataChunkSizeOffset, io.SeekStart); err != nil {
+		return fmt.Errorf("error seeking to DataChunkSize in Close: %s", err)
+	}
+	if err := binary.Write(w.seeker, binary.LittleEndian, dataChunkSize); err != nil {
+		return fmt.Errorf("error rewriting DataChunkSize in Close: %s", err)
+	}
+
+	w.H.DataSize = 36 + dataChunkSize
+	w.H.DataChunkSize = dataChunkSize
+	return nil
+}
+
+// riffHeader mirrors the first 12 bytes of the on-wire wave header: the
+// outer RIFF container ID, its declared size and the WAVE form type.
+type riffHeader struct {
+	RiffID   [4]byte
+	DataSize uint32
+	RiffType [4]byte
+}
+
+// fmtDataHeader mirrors the trailing 32 bytes of the on-wire wave header:
+// the "fmt " chunk followed by the "data" chunk ID/size pair that precedes
+// the raw samples. Header additionally carries FormatExtensible fields
+// (ValidBitsPerSample, ChannelMask, SubFormat) that only Header.WriteTo
+// serializes; fmtDataHeader omits them so the Writer constructors never
+// put them on the wire.
+type fmtDataHeader struct {
+	FmtChunkID    [4]byte
+	FmtChunkSize  uint32
+	AudioFmt      uint16
+	Channels      uint16
+	SamplesPerSec uint32
+	BytesPerSec   uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+	DataChunkID   [4]byte
+	DataChunkSize uint32
+}
+
+// writeCoreHeader writes the 44 byte core of h to w: the riffHeader
+// immediately followed by the fmtDataHeader. NewStreamWriterRF64 writes
+// these two pieces separately so it can splice a leading "ds64" chunk in
+// between, per the RF64 layout.
+func writeCoreHeader(w io.Writer, h *Header) error {
+	rh := riffHeader{RiffID: h.RiffID, DataSize: h.DataSize, RiffType: h.RiffType}
+	if err := binary.Write(w, binary.LittleEndian, &rh); err != nil {
+		return err
+	}
+	return writeFmtDataHeader(w, h)
+}
+
+// writeFmtDataHeader writes the trailing 32 bytes of the core header
+// (everything after RiffType) to w.
+func writeFmtDataHeader(w io.Writer, h *Header) error {
+	fh := fmtDataHeader{
+		FmtChunkID:    h.FmtChunkID,
+		FmtChunkSize:  h.FmtChunkSize,
+		AudioFmt:      h.AudioFmt,
+		Channels:      h.Channels,
+		SamplesPerSec: h.SamplesPerSec,
+		BytesPerSec:   h.BytesPerSec,
+		BlockAlign:    h.BlockAlign,
+		BitsPerSample: h.BitsPerSample,
+		DataChunkID:   h.DataChunkID,
+		DataChunkSize: h.DataChunkSize,
+	}
+	return binary.Write(w, binary.LittleEndian, &fh)
+}
+
+// ds64ChunkSize is the byte size of the "ds64" chunk body written by
+// NewStreamWriterRF64: the RIFF size, data size and sample count, each as
+// a 64 bit little endian integer, followed by a 4 byte chunk-size table
+// length (always 0, since this package never writes auxiliary chunks
+// large enough to need one).
+const ds64ChunkSize = 28
+
+// NewStreamWriterRF64 is like NewStreamWriter but for destinations that do
+// not implement io.WriteSeeker, such as a network socket or pipe. Since
+// such a writer can never seek back to patch the header once the final
+// sample count is known, it instead writes the RF64-style placeholder
+// used by streaming muxers such as ffmpeg: a standard "RIFF"/"WAVE"
+// header with DataSize and DataChunkSize left as UnknownSize, with a
+// "ds64" chunk between "WAVE" and the "fmt " chunk whose 64 bit sizes are
+// likewise left as UnknownSize. Close is a no-op for a Writer created this way, since
+// there is nothing left it can patch; readers should treat the file the
+// same as one whose length was never known and read until io.EOF.
+func NewStreamWriterRF64(w io.Writer, channels, samplesPerSec, bitsPerSample int) (*Writer, error) {
+	h := Header{
+		RiffID:        [4]byte{'R', 'I', 'F', 'F'},
+		DataSize:      UnknownSize,
+		RiffType:      [4]byte{'W', 'A', 'V', 'E'},
+		FmtChunkID:    [4]byte{'f', 'm', 't', ' '},
+		FmtChunkSize:  16,
+		AudioFmt:      FormatPCM,
+		Channels:      uint16(channels),
+		SamplesPerSec: uint32(samplesPerSec),
+		BytesPerSec:   uint32(samplesPerSec * channels * bitsPerSample / 8),
+		BlockAlign:    uint16(channels * bitsPerSample / 8),
+		BitsPerSample: uint16(bitsPerSample),
+		DataChunkID:   [4]byte{'d', 'a', 't', 'a'},
+		DataChunkSize: UnknownSize,
+	}
+
+	rh := riffHeader{RiffID: h.RiffID, DataSize: h.DataSize, RiffType: h.RiffType}
+	if err := binary.Write(w, binary.LittleEndian, &rh); err != nil {
+		return nil, fmt.Errorf("error writing riff header in NewStreamWriterRF64: %s", err)
+	}
+
+	if _, err := w.Write([]byte{'d', 's', '6', '4'}); err != nil {
+		return nil, fmt.Errorf("error writing ds64 chunk ID in NewStreamWriterRF64: %s", err)
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(ds64ChunkSize)); err != nil {
+		return nil, fmt.Errorf("error writing ds64 chunk size in NewStreamWriterRF64: %s", err)
+	}
+	var ds64Body [ds64ChunkSize / 4]uint32 // riffSize, dataSize, sampleCount lo/hi pairs, then table length
+	for i := range ds64Body {
+		ds64Body[i] = UnknownSize
+	}
+	ds64Body[len(ds64Body)-1] = 0 // tableLength: no chunk-size table entries follow
+	if err := binary.Write(w, binary.LittleEndian, &ds64Body); err != nil {
+		return nil, fmt.Errorf("error writing ds64 chunk body in NewStreamWriterRF64: %s", err)
+	}
+
+	if err := writeFmtDataHeader(w, &h); err != nil {
+		return nil, fmt.Errorf("error writing wave header in NewStreamWriterRF64: %s", err)
+	}
+
+	return &Writer{W: w, H: h, numSamples: -1}, nil
 }
 
 // WriteInt writes samples to the wave file. In the []int64 slice passed to WriteInt,
-// each slice element should correspond to a channel in the sample. These are simply
-// cast to the required bit-depth declared when creating the Writer and written to
-// the underlying io.Writer. If the number of samples written exceeds the declared
-// number of samples, an error is raised.
+// each slice element should correspond to a channel in the sample. For PCM data these
+// are simply cast to the required bit-depth declared when creating the Writer; for
+// FormatALaw/FormatMULaw they are clamped to 16 bits and companded via the standard
+// ITU G.711 tables. If the number of samples written exceeds the declared number of
+// samples, an error is raised.
 func (w *Writer) WriteInt(samples []int64) error {
 	if len(samples) != int(w.H.Channels) {
 		return fmt.Errorf("number of samples != channels in WriteInt: want %d: got %d", w.H.Channels, len(samples))
 	}
 
-	if w.ctr+1 > w.numSamples {
+	if w.numSamples >= 0 && w.ctr+1 > w.numSamples {
 		return fmt.Errorf("overflow error: attempting to write too many samples: already wrote %d", w.ctr)
 	}
 
+	fmtCode := w.H.EffectiveAudioFmt()
+	if fmtCode == FormatIEEEFloat {
+		return fmt.Errorf("cannot WriteInt to an IEEE float wave file: use WriteFloat")
+	}
+
 	var reterr error
-	switch w.H.BitsPerSample {
-	case 8:
+	switch {
+	case fmtCode == FormatALaw || fmtCode == FormatMULaw:
+		wsamples := make([]byte, w.H.Channels)
+		for j, sample := range samples {
+			wsamples[j] = encodeCompanded(fmtCode, clipInt16(sample))
+		}
+		if err := binary.Write(w.W, binary.LittleEndian, wsamples); err != nil {
+			reterr = err
+		}
+	case w.H.BitsPerSample == 8:
 		wsamples := make([]int8, w.H.Channels)
 		for j, sample := range samples {
 			wsamples[j] = int8(sample)
@@ -68,7 +283,7 @@ func (w *Writer) WriteInt(samples []int64) error {
 		if err := binary.Write(w.W, binary.LittleEndian, wsamples); err != nil {
 			reterr = err
 		}
-	case 16:
+	case w.H.BitsPerSample == 16:
 		wsamples := make([]int16, w.H.Channels)
 		for j, sample := range samples {
 			wsamples[j] = int16(sample)
@@ -76,7 +291,7 @@ func (w *Writer) WriteInt(samples []int64) error {
 		if err := binary.Write(w.W, binary.LittleEndian, wsamples); err != nil {
 			reterr = err
 		}
-	case 32:
+	case w.H.BitsPerSample == 32:
 		wsamples := make([]int32, w.H.Channels)
 		for j, sample := range samples {
 			wsamples[j] = int32(sample)
@@ -94,29 +309,38 @@ func (w *Writer) WriteInt(samples []int64) error {
 	return fmt.Errorf("error writing sample in WriteInt:%s", reterr)
 }
 
-// WriteFloat writes samples to the wave file. In the []float64 slice passed to WriteInt,
-// each slice element should correspond to a channel in the sample. These are simply
-// cast to 32 bit floats and written to the underlying io.Writer. If the number of
-// samples written exceeds the declared number of samples, an error is raised.
+// WriteFloat writes samples to the wave file. In the []float64 slice passed to WriteFloat,
+// each slice element should correspond to a channel in the sample. These are cast to the
+// declared bit-depth (32 or 64 bit IEEE float) and written to the underlying io.Writer. If
+// the number of samples written exceeds the declared number of samples, an error is raised.
 func (w *Writer) WriteFloat(samples []float64) error {
 	if len(samples) != int(w.H.Channels) {
-		return fmt.Errorf("number of samples != channels in WriteInt: want %d: got %d", w.H.Channels, len(samples))
+		return fmt.Errorf("number of samples != channels in WriteFloat: want %d: got %d", w.H.Channels, len(samples))
 	}
 
-	if w.ctr+1 > w.numSamples {
+	if w.numSamples >= 0 && w.ctr+1 > w.numSamples {
 		return fmt.Errorf("overflow error: attempting to write too many samples: already wrote %d", w.ctr)
 	}
 
-	if w.H.BitsPerSample != 32 {
-		return fmt.Errorf("only 32 bit floats are supported. bitsPerSample in Header is set to: %d", w.H.BitsPerSample)
+	if w.H.EffectiveAudioFmt() != FormatIEEEFloat {
+		return fmt.Errorf("cannot WriteFloat to a non IEEE float wave file: AudioFmt %d", w.H.AudioFmt)
 	}
 
-	wsamples := make([]float32, w.H.Channels)
-	for j, sample := range samples {
-		wsamples[j] = float32(sample)
-	}
-	if err := binary.Write(w.W, binary.LittleEndian, wsamples); err != nil {
-		return fmt.Errorf("error writing sample in WriteFloat: %s", err)
+	switch w.H.BitsPerSample {
+	case 32:
+		wsamples := make([]float32, w.H.Channels)
+		for j, sample := range samples {
+			wsamples[j] = float32(sample)
+		}
+		if err := binary.Write(w.W, binary.LittleEndian, wsamples); err != nil {
+			return fmt.Errorf("error writing sample in WriteFloat: %s", err)
+		}
+	case 64:
+		if err := binary.Write(w.W, binary.LittleEndian, samples); err != nil {
+			return fmt.Errorf("error writing sample in WriteFloat: %s", err)
+		}
+	default:
+		return fmt.Errorf("only 32 or 64 bit floats are supported. bitsPerSample in Header is set to: %d", w.H.BitsPerSample)
 	}
 
 	w.ctr++